@@ -0,0 +1,320 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// IndexFunc computes the set of index keys an endpoint should be filed under
+// for a named index, e.g. all of an endpoint's IPs for the "ipv4" index.
+type IndexFunc func(ep *Endpoint) []string
+
+// Names of the indices maintained by default.
+const (
+	ByIPv4        = "ipv4"
+	ByIPv6        = "ipv6"
+	ByPodName     = "podName"
+	ByNamespace   = "namespace"
+	ByContainerID = "containerID"
+)
+
+func ipv4IndexFunc(ep *Endpoint) []string {
+	if ep.IPv4 == nil {
+		return nil
+	}
+	return []string{ep.IPv4.String()}
+}
+
+func ipv6IndexFunc(ep *Endpoint) []string {
+	if ep.IPv6 == nil {
+		return nil
+	}
+	return []string{ep.IPv6.String()}
+}
+
+func podNameIndexFunc(ep *Endpoint) []string {
+	if ep.PodName == "" {
+		return nil
+	}
+	return []string{ep.PodNamespace + "/" + ep.PodName}
+}
+
+func namespaceIndexFunc(ep *Endpoint) []string {
+	return []string{ep.PodNamespace}
+}
+
+func containerIDIndexFunc(ep *Endpoint) []string {
+	return ep.ContainerIDs
+}
+
+// Endpoints is a thread-safe, multi-indexed store of endpoints, modeled
+// after the Kubernetes threadSafeMap/Indexer used by shared informer caches.
+// The canonical objects live in a map keyed by endpoint ID; named secondary
+// indices keep sets of endpoint IDs so that lookups like GetEndpoint and
+// FindEPs are O(1) hash hits followed by a dedup through a small set,
+// instead of a linear scan over every known endpoint.
+type Endpoints struct {
+	mutex sync.RWMutex
+
+	eps map[uint64]*Endpoint
+
+	indexers IndexerFuncs
+	indices  map[string]map[string]sets.String
+}
+
+// IndexerFuncs is a named collection of IndexFunc.
+type IndexerFuncs map[string]IndexFunc
+
+// defaultIndexers are registered on every new Endpoints store.
+func defaultIndexers() IndexerFuncs {
+	return IndexerFuncs{
+		ByIPv4:        ipv4IndexFunc,
+		ByIPv6:        ipv6IndexFunc,
+		ByPodName:     podNameIndexFunc,
+		ByNamespace:   namespaceIndexFunc,
+		ByContainerID: containerIDIndexFunc,
+	}
+}
+
+// NewEndpoints returns an empty, ready to use Endpoints store.
+func NewEndpoints() *Endpoints {
+	indexers := defaultIndexers()
+	indices := make(map[string]map[string]sets.String, len(indexers))
+	for name := range indexers {
+		indices[name] = make(map[string]sets.String)
+	}
+	return &Endpoints{
+		eps:      make(map[uint64]*Endpoint),
+		indexers: indexers,
+		indices:  indices,
+	}
+}
+
+// idKey is the string form of an endpoint ID used as the value stored in
+// index buckets.
+func idKey(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+// indexEndpoint files ep under every key every registered indexer produces
+// for it. Callers must hold es.mutex for writing.
+func (es *Endpoints) indexEndpoint(ep *Endpoint) {
+	key := idKey(ep.ID)
+	for name, fn := range es.indexers {
+		for _, k := range fn(ep) {
+			bucket, ok := es.indices[name][k]
+			if !ok {
+				bucket = sets.NewString()
+				es.indices[name][k] = bucket
+			}
+			bucket.Insert(key)
+		}
+	}
+}
+
+// unindexEndpoint removes ep from every index bucket it was filed under.
+// Callers must hold es.mutex for writing.
+func (es *Endpoints) unindexEndpoint(ep *Endpoint) {
+	key := idKey(ep.ID)
+	for name, fn := range es.indexers {
+		for _, k := range fn(ep) {
+			bucket, ok := es.indices[name][k]
+			if !ok {
+				continue
+			}
+			bucket.Delete(key)
+			if bucket.Len() == 0 {
+				delete(es.indices[name], k)
+			}
+		}
+	}
+}
+
+// Index returns the endpoints that share an index key with exemplar on the
+// named index, e.g. Index(ByPodName, &Endpoint{PodName: "x", PodNamespace:
+// "default"}) returns every endpoint running as "default/x". It is exposed
+// so that downstream consumers, such as the allow/deny filter, can reuse the
+// same indices instead of building their own.
+func (es *Endpoints) Index(name string, exemplar *Endpoint) ([]*Endpoint, error) {
+	fn, ok := es.indexers[name]
+	if !ok {
+		return nil, fmt.Errorf("no such index %q", name)
+	}
+
+	es.mutex.RLock()
+	defer es.mutex.RUnlock()
+
+	matched := sets.NewString()
+	for _, k := range fn(exemplar) {
+		matched = matched.Union(es.indices[name][k])
+	}
+
+	eps := make([]*Endpoint, 0, matched.Len())
+	for _, key := range matched.List() {
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ep, ok := es.eps[id]; ok {
+			eps = append(eps, ep)
+		}
+	}
+	return eps, nil
+}
+
+// SyncEndpoints adds the given list of endpoints to the store. Every
+// endpoint currently in the store that is not found in 'newEps' is marked as
+// "deleted".
+func (es *Endpoints) SyncEndpoints(newEps []*Endpoint) {
+	if len(newEps) == 0 {
+		return
+	}
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	// Mark all endpoints not found as deleted
+	for _, ep := range es.eps {
+		if ep.Deleted != nil {
+			continue
+		}
+		found := false
+		for _, updatedEp := range newEps {
+			if ep.EqualsByID(updatedEp) {
+				found = true
+				break
+			}
+		}
+		// If we haven't found it, it means we have lost, or haven't receive
+		// yet, an event signalizing that this endpoint was deleted.
+		if !found {
+			t := time.Now()
+			// TODO: remove leftover endpoints if the timestamp of the last
+			//  flow written is after the endpoint was deleted.
+			//  This requires a method in the ring buffer that returns
+			//  the older flow written.
+			ep.Deleted = &t
+		}
+	}
+
+	for _, updatedEp := range newEps {
+		es.updateEndpoint(updatedEp)
+	}
+}
+
+// updateEndpoint updates the given endpoint if it already exists in the
+// store. If it does not exist, it is added. Callers must hold es.mutex for
+// writing.
+func (es *Endpoints) updateEndpoint(updateEp *Endpoint) {
+	if existing, ok := es.eps[updateEp.ID]; ok && existing.Deleted == nil {
+		es.unindexEndpoint(existing)
+		existing.SetFrom(updateEp)
+		es.indexEndpoint(existing)
+		return
+	}
+
+	if existing, ok := es.eps[updateEp.ID]; ok {
+		es.unindexEndpoint(existing)
+	}
+	es.eps[updateEp.ID] = updateEp
+	es.indexEndpoint(updateEp)
+}
+
+// UpdateEndpoint updates the given endpoint if it already exists in the
+// store. If it does not exist, it is added.
+func (es *Endpoints) UpdateEndpoint(updateEp *Endpoint) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.updateEndpoint(updateEp)
+}
+
+// MarkDeleted marks the given endpoint as deleted by setting the "Deleted"
+// field to the value of the given 'del' endpoint. If the endpoint is not
+// found in the store, it's added as already deleted.
+func (es *Endpoints) MarkDeleted(del *Endpoint) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	if existing, ok := es.eps[del.ID]; ok && existing.Deleted == nil && existing.EqualsByID(del) {
+		existing.Deleted = del.Deleted
+		return
+	}
+
+	if existing, ok := es.eps[del.ID]; ok {
+		es.unindexEndpoint(existing)
+	}
+	es.eps[del.ID] = del
+	es.indexEndpoint(del)
+}
+
+// GetEndpoint returns the endpoint that has the given ip.
+func (es *Endpoints) GetEndpoint(ip net.IP) (endpoint *Endpoint, ok bool) {
+	es.mutex.RLock()
+	defer es.mutex.RUnlock()
+
+	index, ipKey := ByIPv4, ip.String()
+	if ip.To4() == nil {
+		index = ByIPv6
+	}
+
+	for idStr := range es.indices[index][ipKey] {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ep, ok := es.eps[id]; ok {
+			return ep, true
+		}
+	}
+	return nil, false
+}
+
+// FindEPs returns all the endpoints that have the given epID, or the given
+// podName running in the given namespace, or that simply run in the given
+// namespace when podName is empty.
+func (es *Endpoints) FindEPs(epID uint64, namespace string, podName string) []Endpoint {
+	es.mutex.RLock()
+	defer es.mutex.RUnlock()
+
+	matched := sets.NewString()
+	if epID != 0 {
+		if _, ok := es.eps[epID]; ok {
+			matched.Insert(idKey(epID))
+		}
+	}
+	if podName != "" {
+		matched = matched.Union(es.indices[ByPodName][namespace+"/"+podName])
+	} else {
+		matched = matched.Union(es.indices[ByNamespace][namespace])
+	}
+
+	eps := make([]Endpoint, 0, matched.Len())
+	for _, key := range matched.List() {
+		id, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ep, ok := es.eps[id]; ok {
+			eps = append(eps, *ep)
+		}
+	}
+	return eps
+}
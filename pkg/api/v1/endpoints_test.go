@@ -0,0 +1,135 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoints_GetEndpoint(t *testing.T) {
+	es := NewEndpoints()
+	es.UpdateEndpoint(&Endpoint{
+		ID:           1,
+		IPv4:         net.ParseIP("1.1.1.1"),
+		PodName:      "xwing",
+		PodNamespace: "default",
+	})
+
+	ep, ok := es.GetEndpoint(net.ParseIP("1.1.1.1"))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), ep.ID)
+
+	_, ok = es.GetEndpoint(net.ParseIP("2.2.2.2"))
+	assert.False(t, ok)
+}
+
+func TestEndpoints_UpdateEndpoint_reindexesOnIPChange(t *testing.T) {
+	es := NewEndpoints()
+	es.UpdateEndpoint(&Endpoint{ID: 1, IPv4: net.ParseIP("1.1.1.1")})
+	es.UpdateEndpoint(&Endpoint{ID: 1, IPv4: net.ParseIP("2.2.2.2")})
+
+	_, ok := es.GetEndpoint(net.ParseIP("1.1.1.1"))
+	assert.False(t, ok)
+
+	ep, ok := es.GetEndpoint(net.ParseIP("2.2.2.2"))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), ep.ID)
+}
+
+func TestEndpoints_FindEPs(t *testing.T) {
+	es := NewEndpoints()
+	es.UpdateEndpoint(&Endpoint{ID: 1, PodName: "xwing", PodNamespace: "default"})
+	es.UpdateEndpoint(&Endpoint{ID: 2, PodName: "deathstar", PodNamespace: "kube-system"})
+
+	eps := es.FindEPs(0, "default", "xwing")
+	assert.Len(t, eps, 1)
+	assert.Equal(t, uint64(1), eps[0].ID)
+
+	eps = es.FindEPs(0, "kube-system", "")
+	assert.Len(t, eps, 1)
+	assert.Equal(t, uint64(2), eps[0].ID)
+
+	// epID=2 matches ep-2 directly, and since podName is empty that result
+	// is unioned with every endpoint in the "default" namespace (ep-1) --
+	// this "OR" across all three conditions is the preexisting semantics of
+	// FindEPs that this store is required to preserve.
+	eps = es.FindEPs(2, "default", "")
+	ids := []uint64{eps[0].ID, eps[1].ID}
+	assert.ElementsMatch(t, []uint64{1, 2}, ids)
+}
+
+func TestEndpoints_SyncEndpoints_marksMissingAsDeleted(t *testing.T) {
+	es := NewEndpoints()
+	es.UpdateEndpoint(&Endpoint{ID: 1, PodName: "xwing", PodNamespace: "default"})
+	es.UpdateEndpoint(&Endpoint{ID: 2, PodName: "deathstar", PodNamespace: "kube-system"})
+
+	es.SyncEndpoints([]*Endpoint{{ID: 1, PodName: "xwing", PodNamespace: "default"}})
+
+	es.mutex.RLock()
+	defer es.mutex.RUnlock()
+	assert.Nil(t, es.eps[1].Deleted)
+	assert.NotNil(t, es.eps[2].Deleted)
+}
+
+func TestEndpoints_Index(t *testing.T) {
+	es := NewEndpoints()
+	es.UpdateEndpoint(&Endpoint{ID: 1, PodName: "xwing", PodNamespace: "default"})
+	es.UpdateEndpoint(&Endpoint{ID: 2, PodName: "ywing", PodNamespace: "default"})
+
+	eps, err := es.Index(ByNamespace, &Endpoint{PodNamespace: "default"})
+	assert.NoError(t, err)
+	assert.Len(t, eps, 2)
+
+	_, err = es.Index("no-such-index", &Endpoint{})
+	assert.Error(t, err)
+}
+
+func benchmarkEndpoints(n int) *Endpoints {
+	es := NewEndpoints()
+	for i := 0; i < n; i++ {
+		es.UpdateEndpoint(&Endpoint{
+			ID:           uint64(i + 1),
+			IPv4:         net.ParseIP(fmt.Sprintf("10.%d.%d.%d", (i>>16)&0xff, (i>>8)&0xff, i&0xff)),
+			PodName:      fmt.Sprintf("pod-%d", i),
+			PodNamespace: "default",
+		})
+	}
+	return es
+}
+
+func BenchmarkEndpoints_GetEndpoint_10k(b *testing.B) {
+	es := benchmarkEndpoints(10000)
+	ip := net.ParseIP("10.0.0.128")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		es.GetEndpoint(ip)
+	}
+}
+
+func BenchmarkEndpoints_FindEPs_10k(b *testing.B) {
+	es := benchmarkEndpoints(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		es.FindEPs(0, "default", "pod-5000")
+	}
+}
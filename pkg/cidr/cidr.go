@@ -0,0 +1,147 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cidr provides radix-trie backed CIDR sets that support
+// longest-prefix-match lookups in O(prefix length) rather than O(n) over a
+// list of parsed networks.
+package cidr
+
+import "net"
+
+// node is a single bit-level trie node. children[0] is the branch taken when
+// the next bit is 0, children[1] when it is 1. value is only meaningful when
+// hasValue is true, i.e. a prefix was explicitly inserted that ends at this
+// node.
+type node struct {
+	children [2]*node
+	value    interface{}
+	hasValue bool
+}
+
+// Tree4 is a radix trie keyed on the 32 bits of an IPv4 address, used to
+// answer longest-prefix-match queries over a set of inserted CIDRs.
+type Tree4 struct {
+	root *node
+}
+
+// Tree6 is the IPv6 equivalent of Tree4, keyed on 128 bits.
+type Tree6 struct {
+	root *node
+}
+
+// NewTree4 returns an empty IPv4 CIDR tree.
+func NewTree4() *Tree4 {
+	return &Tree4{root: &node{}}
+}
+
+// NewTree6 returns an empty IPv6 CIDR tree.
+func NewTree6() *Tree6 {
+	return &Tree6{root: &node{}}
+}
+
+// Insert adds prefix to the tree with the given value. If prefix was already
+// present, its value is overwritten.
+func (t *Tree4) Insert(prefix *net.IPNet, value interface{}) {
+	ones, bits := prefix.Mask.Size()
+	if bits != 32 {
+		return
+	}
+	insert(t.root, prefix.IP.To4(), ones, value)
+}
+
+// Insert adds prefix to the tree with the given value. If prefix was already
+// present, its value is overwritten.
+func (t *Tree6) Insert(prefix *net.IPNet, value interface{}) {
+	ones, bits := prefix.Mask.Size()
+	if bits != 128 {
+		return
+	}
+	insert(t.root, prefix.IP.To16(), ones, value)
+}
+
+func insert(root *node, ip net.IP, ones int, value interface{}) {
+	n := root
+	for i := 0; i < ones; i++ {
+		bit := bitAt(ip, i)
+		if n.children[bit] == nil {
+			n.children[bit] = &node{}
+		}
+		n = n.children[bit]
+	}
+	n.value = value
+	n.hasValue = true
+}
+
+// Contains returns the value of any prefix in the tree that covers ip. When
+// several prefixes cover ip, the most specific one wins, matching
+// MostSpecificContains.
+func (t *Tree4) Contains(ip net.IP) (interface{}, bool) {
+	return t.MostSpecificContains(ip)
+}
+
+// Contains returns the value of any prefix in the tree that covers ip. When
+// several prefixes cover ip, the most specific one wins, matching
+// MostSpecificContains.
+func (t *Tree6) Contains(ip net.IP) (interface{}, bool) {
+	return t.MostSpecificContains(ip)
+}
+
+// MostSpecificContains walks the trie along ip's bits and returns the value
+// stored at the deepest node that has one, i.e. the longest prefix that
+// covers ip.
+func (t *Tree4) MostSpecificContains(ip net.IP) (interface{}, bool) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, false
+	}
+	return mostSpecificContains(t.root, ip4, 32)
+}
+
+// MostSpecificContains walks the trie along ip's bits and returns the value
+// stored at the deepest node that has one, i.e. the longest prefix that
+// covers ip.
+func (t *Tree6) MostSpecificContains(ip net.IP) (interface{}, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil || ip.To4() != nil {
+		return nil, false
+	}
+	return mostSpecificContains(t.root, ip16, 128)
+}
+
+func mostSpecificContains(root *node, ip net.IP, maxBits int) (interface{}, bool) {
+	n := root
+	var value interface{}
+	found := false
+	if n.hasValue {
+		value, found = n.value, true
+	}
+	for i := 0; i < maxBits; i++ {
+		bit := bitAt(ip, i)
+		n = n.children[bit]
+		if n == nil {
+			break
+		}
+		if n.hasValue {
+			value, found = n.value, true
+		}
+	}
+	return value, found
+}
+
+// bitAt returns the i-th most significant bit of ip, numbered from 0.
+func bitAt(ip net.IP, i int) int {
+	byteIdx := i / 8
+	bitIdx := uint(7 - i%8)
+	return int((ip[byteIdx] >> bitIdx) & 1)
+}
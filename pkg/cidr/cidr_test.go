@@ -0,0 +1,111 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cidr
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	require.NoError(t, err)
+	return n
+}
+
+func TestTree4_MostSpecificContains(t *testing.T) {
+	tree := NewTree4()
+	tree.Insert(mustParseCIDR(t, "1.1.0.0/16"), "/16")
+	tree.Insert(mustParseCIDR(t, "1.1.1.0/24"), "/24")
+	tree.Insert(mustParseCIDR(t, "1.1.1.1/32"), "/32")
+
+	v, ok := tree.MostSpecificContains(net.ParseIP("1.1.1.1"))
+	require.True(t, ok)
+	assert.Equal(t, "/32", v)
+
+	v, ok = tree.MostSpecificContains(net.ParseIP("1.1.1.2"))
+	require.True(t, ok)
+	assert.Equal(t, "/24", v)
+
+	v, ok = tree.MostSpecificContains(net.ParseIP("1.1.2.1"))
+	require.True(t, ok)
+	assert.Equal(t, "/16", v)
+
+	_, ok = tree.MostSpecificContains(net.ParseIP("2.2.2.2"))
+	assert.False(t, ok)
+}
+
+func TestTree6_MostSpecificContains(t *testing.T) {
+	tree := NewTree6()
+	tree.Insert(mustParseCIDR(t, "fd00::/8"), "/8")
+	tree.Insert(mustParseCIDR(t, "fd00:10::/32"), "/32")
+
+	v, ok := tree.MostSpecificContains(net.ParseIP("fd00:10::1"))
+	require.True(t, ok)
+	assert.Equal(t, "/32", v)
+
+	v, ok = tree.MostSpecificContains(net.ParseIP("fd00:20::1"))
+	require.True(t, ok)
+	assert.Equal(t, "/8", v)
+
+	_, ok = tree.MostSpecificContains(net.ParseIP("fe00::1"))
+	assert.False(t, ok)
+}
+
+func TestTree4_Insert_overwrite(t *testing.T) {
+	tree := NewTree4()
+	tree.Insert(mustParseCIDR(t, "1.1.1.1/32"), "first")
+	tree.Insert(mustParseCIDR(t, "1.1.1.1/32"), "second")
+
+	v, ok := tree.MostSpecificContains(net.ParseIP("1.1.1.1"))
+	require.True(t, ok)
+	assert.Equal(t, "second", v)
+}
+
+func BenchmarkTree4_MostSpecificContains(b *testing.B) {
+	tree := NewTree4()
+	_, n1, _ := net.ParseCIDR("10.0.0.0/8")
+	_, n2, _ := net.ParseCIDR("10.16.0.0/16")
+	_, n3, _ := net.ParseCIDR("10.16.236.0/24")
+	tree.Insert(n1, "/8")
+	tree.Insert(n2, "/16")
+	tree.Insert(n3, "/24")
+	ip := net.ParseIP("10.16.236.178")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tree.MostSpecificContains(ip)
+	}
+}
+
+func BenchmarkTree6_MostSpecificContains(b *testing.B) {
+	tree := NewTree6()
+	_, n1, _ := net.ParseCIDR("fd00::/8")
+	_, n2, _ := net.ParseCIDR("fd00:10::/32")
+	tree.Insert(n1, "/8")
+	tree.Insert(n2, "/32")
+	ip := net.ParseIP("fd00:10::1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tree.MostSpecificContains(ip)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exporter defines the interface shared by all flow sinks, e.g.
+// pkg/exporter/syslog, so that additional exporters (Kafka, Loki, ...) can
+// be added without touching the parser or the observer pipeline.
+package exporter
+
+import (
+	pb "github.com/cilium/hubble/api/v1/observer"
+)
+
+// Sink consumes decoded flows and ships them to an external system. Export
+// must not block the caller for longer than it takes to enqueue f; any
+// bounding/backpressure handling is the sink's own responsibility.
+type Sink interface {
+	// Export hands f to the sink. Implementations should return quickly and
+	// do any I/O asynchronously.
+	Export(f *pb.Flow) error
+
+	// Close flushes any pending state and releases the sink's resources.
+	Close() error
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	pb "github.com/cilium/hubble/api/v1/observer"
+)
+
+var _ Sink = (*Pipeline)(nil)
+
+// Pipeline fans a decoded flow out to every configured Sink, e.g. a
+// pkg/exporter/syslog.Exporter alongside future Kafka/Loki sinks, so the
+// observer pipeline has a single Sink to hand flows to regardless of how
+// many are actually configured.
+type Pipeline struct {
+	sinks []Sink
+}
+
+// NewPipeline returns a Pipeline that exports every flow to each of sinks,
+// in order.
+func NewPipeline(sinks ...Sink) *Pipeline {
+	return &Pipeline{sinks: sinks}
+}
+
+// Export hands f to every sink, continuing past individual failures so that
+// one broken sink cannot stop flows from reaching the others. It returns the
+// first error encountered, if any.
+func (p *Pipeline) Export(f *pb.Flow) error {
+	var firstErr error
+	for _, s := range p.sinks {
+		if err := s.Export(f); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes every sink, continuing past individual failures, and returns
+// the first error encountered, if any.
+func (p *Pipeline) Close() error {
+	var firstErr error
+	for _, s := range p.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
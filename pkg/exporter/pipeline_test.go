@@ -0,0 +1,102 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/cilium/hubble/api/v1/observer"
+	"github.com/cilium/hubble/pkg/exporter/syslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSink struct {
+	exported  []*pb.Flow
+	closed    bool
+	exportErr error
+}
+
+func (f *fakeSink) Export(flow *pb.Flow) error {
+	f.exported = append(f.exported, flow)
+	return f.exportErr
+}
+
+func (f *fakeSink) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestPipeline_fansOutToEverySink(t *testing.T) {
+	a, b := &fakeSink{}, &fakeSink{}
+	p := NewPipeline(a, b)
+
+	flow := &pb.Flow{NodeName: "k8s1"}
+	require.NoError(t, p.Export(flow))
+
+	assert.Equal(t, []*pb.Flow{flow}, a.exported)
+	assert.Equal(t, []*pb.Flow{flow}, b.exported)
+}
+
+func TestPipeline_exportContinuesPastSinkError(t *testing.T) {
+	failing := &fakeSink{exportErr: errors.New("boom")}
+	ok := &fakeSink{}
+	p := NewPipeline(failing, ok)
+
+	err := p.Export(&pb.Flow{})
+	assert.EqualError(t, err, "boom")
+	assert.Len(t, ok.exported, 1)
+}
+
+func TestPipeline_closeClosesEverySink(t *testing.T) {
+	a, b := &fakeSink{}, &fakeSink{}
+	p := NewPipeline(a, b)
+
+	require.NoError(t, p.Close())
+	assert.True(t, a.closed)
+	assert.True(t, b.closed)
+}
+
+// TestPipeline_withSyslogSink proves Pipeline composes with a real Sink
+// implementation rather than only a fake: flows handed to the Pipeline
+// actually reach a syslog.Exporter's UDP collector.
+func TestPipeline_withSyslogSink(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	se, err := syslog.New(syslog.Config{
+		Network:   "udp",
+		Address:   pc.LocalAddr().String(),
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	defer se.Close()
+
+	fake := &fakeSink{}
+	p := NewPipeline(se, fake)
+
+	require.NoError(t, p.Export(&pb.Flow{NodeName: "xwing"}))
+	assert.Len(t, fake.exported, 1)
+
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf[:n]), "xwing")
+}
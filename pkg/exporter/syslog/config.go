@@ -0,0 +1,67 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import "crypto/tls"
+
+// Facility is an RFC 5424 facility code.
+type Facility int
+
+// Facilities relevant to a network flow exporter.
+const (
+	FacilityLocal0 Facility = 16
+	FacilityLocal1 Facility = 17
+)
+
+// Config configures a syslog Exporter.
+type Config struct {
+	// Facility is the RFC 5424 facility code to tag every message with.
+	Facility Facility
+	// Tag is the RFC 5424 APP-NAME field, e.g. "hubble".
+	Tag string
+	// Network is one of "udp", "tcp" or "tls".
+	Network string
+	// Address is the remote syslog collector's host:port.
+	Address string
+	// TLSConfig is used when Network is "tls". Ignored otherwise.
+	TLSConfig *tls.Config
+	// BatchSize is the number of messages written per network flush.
+	BatchSize int
+	// QueueSize bounds the in-memory queue of flows awaiting export. Once
+	// full, the oldest queued flow is dropped to make room for the newest
+	// one so a slow collector cannot backpressure the flow pipeline.
+	QueueSize int
+}
+
+func (c Config) queueSize() int {
+	if c.QueueSize <= 0 {
+		return 1024
+	}
+	return c.QueueSize
+}
+
+func (c Config) batchSize() int {
+	if c.BatchSize <= 0 {
+		return 1
+	}
+	return c.BatchSize
+}
+
+func (c Config) tag() string {
+	if c.Tag == "" {
+		return "hubble"
+	}
+	return c.Tag
+}
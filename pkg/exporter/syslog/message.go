@@ -0,0 +1,153 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/cilium/hubble/api/v1/observer"
+)
+
+// enterpriseID is the IANA-reserved "example" private enterprise number,
+// used here as the SD-ID for hubble's structured-data element since hubble
+// does not have one registered of its own.
+const enterpriseID = "32473"
+
+const rfc5424Version = 1
+
+// formatRFC5424 renders f as an RFC 5424 syslog message with a structured
+// data element carrying the flow's high-value attributes.
+func formatRFC5424(f *pb.Flow, facility Facility, sev severity, tag string) string {
+	pri := int(facility)*8 + int(sev)
+
+	ts := time.Now().UTC()
+	if t := f.GetTime(); t != nil {
+		ts = time.Unix(t.GetSeconds(), int64(t.GetNanos())).UTC()
+	}
+
+	hostname := f.GetNodeName()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	pid := os.Getpid()
+
+	sd := formatStructuredData(f)
+
+	return fmt.Sprintf("<%d>%d %s %s %s %d - %s %s",
+		pri,
+		rfc5424Version,
+		ts.Format(time.RFC3339Nano),
+		hostname,
+		tag,
+		pid,
+		sd,
+		flowSummary(f),
+	)
+}
+
+// formatStructuredData builds the "[hubble@32473 key=\"value\" ...]" element
+// carrying the flow's high-value attributes.
+func formatStructuredData(f *pb.Flow) string {
+	pairs := []struct {
+		key, value string
+	}{
+		{"source_pod_namespace", f.GetSource().GetNamespace()},
+		{"source_pod_name", f.GetSource().GetPodName()},
+		{"source_labels", strings.Join(f.GetSource().GetLabels(), ",")},
+		{"destination_pod_namespace", f.GetDestination().GetNamespace()},
+		{"destination_pod_name", f.GetDestination().GetPodName()},
+		{"destination_labels", strings.Join(f.GetDestination().GetLabels(), ",")},
+		{"verdict", f.GetVerdict().String()},
+		{"drop_reason", strconv.Itoa(int(f.GetDropReason()))},
+		{"tcp_flags", tcpFlagsString(f)},
+		{"event_type", strconv.Itoa(int(f.GetEventType().GetType()))},
+		{"event_sub_type", strconv.Itoa(int(f.GetEventType().GetSubType()))},
+		{"node_name", f.GetNodeName()},
+	}
+
+	var b strings.Builder
+	b.WriteByte('[')
+	b.WriteString("hubble@")
+	b.WriteString(enterpriseID)
+	for _, p := range pairs {
+		if p.value == "" {
+			continue
+		}
+		b.WriteByte(' ')
+		b.WriteString(p.key)
+		b.WriteString(`="`)
+		b.WriteString(escapeSDParamValue(p.value))
+		b.WriteByte('"')
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// escapeSDParamValue backslash-escapes the three characters RFC 5424 requires
+// escaped inside an SD-PARAM value: '"', '\' and ']'. '\' is escaped first so
+// that escaping '"' and ']' doesn't double-escape the backslashes introduced
+// by that first pass.
+func escapeSDParamValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, `]`, `\]`)
+	return s
+}
+
+func tcpFlagsString(f *pb.Flow) string {
+	flags := f.GetL4().GetTCP().GetFlags()
+	if flags == nil {
+		return ""
+	}
+
+	var set []string
+	if flags.FIN {
+		set = append(set, "FIN")
+	}
+	if flags.SYN {
+		set = append(set, "SYN")
+	}
+	if flags.RST {
+		set = append(set, "RST")
+	}
+	if flags.PSH {
+		set = append(set, "PSH")
+	}
+	if flags.ACK {
+		set = append(set, "ACK")
+	}
+	if flags.URG {
+		set = append(set, "URG")
+	}
+	if flags.ECE {
+		set = append(set, "ECE")
+	}
+	if flags.CWR {
+		set = append(set, "CWR")
+	}
+	if flags.NS {
+		set = append(set, "NS")
+	}
+	return strings.Join(set, ",")
+}
+
+func flowSummary(f *pb.Flow) string {
+	return fmt.Sprintf("%s -> %s", f.GetIP().GetSource(), f.GetIP().GetDestination())
+}
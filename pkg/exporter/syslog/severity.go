@@ -0,0 +1,49 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	pb "github.com/cilium/hubble/api/v1/observer"
+)
+
+// severity is an RFC 5424 severity level.
+type severity int
+
+// Severities used by the syslog exporter. Only the subset hubble flows can
+// actually produce is defined here.
+const (
+	severityWarning severity = 4
+	severityNotice  severity = 5
+	severityInfo    severity = 6
+)
+
+// dropReasonPolicyDenied is the lowest drop reason code reserved for
+// policy-enforcement drops, mirroring the agent's own drop reason layout.
+const dropReasonPolicyDenied = 128
+
+// severityFor maps a decoded flow to an RFC 5424 severity: dropped traffic
+// (or any drop reason in the policy-denied range) is a WARNING, forwarded
+// traffic is INFO, and anything else -- most notably policy-verdict
+// failures that did not result in an outright drop -- is a NOTICE.
+func severityFor(f *pb.Flow) severity {
+	switch {
+	case f.GetVerdict() == pb.Verdict_DROPPED, f.GetDropReason() >= dropReasonPolicyDenied:
+		return severityWarning
+	case f.GetVerdict() == pb.Verdict_FORWARDED:
+		return severityInfo
+	default:
+		return severityNotice
+	}
+}
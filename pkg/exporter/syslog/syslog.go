@@ -0,0 +1,156 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package syslog implements an exporter.Sink that ships decoded flows to a
+// remote RFC 5424 syslog collector over UDP, TCP or TLS.
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	pb "github.com/cilium/hubble/api/v1/observer"
+	"github.com/cilium/hubble/pkg/exporter"
+)
+
+var _ exporter.Sink = (*Exporter)(nil)
+
+// Exporter is an exporter.Sink that formats flows as RFC 5424 messages and
+// writes them to a remote syslog collector. A slow or unreachable collector
+// never blocks the flow pipeline: Export enqueues onto a bounded queue with
+// drop-oldest semantics, and a single background goroutine drains it.
+type Exporter struct {
+	cfg Config
+
+	mu   sync.Mutex
+	conn net.Conn
+
+	queue chan *pb.Flow
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New dials cfg.Network/cfg.Address and returns an Exporter that writes to
+// it in the background.
+func New(cfg Config) (*Exporter, error) {
+	e := &Exporter{
+		cfg:   cfg,
+		queue: make(chan *pb.Flow, cfg.queueSize()),
+		done:  make(chan struct{}),
+	}
+
+	conn, err := dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	e.conn = conn
+
+	e.wg.Add(1)
+	go e.run()
+
+	return e, nil
+}
+
+func dial(cfg Config) (net.Conn, error) {
+	switch cfg.Network {
+	case "tls":
+		return tls.Dial("tcp", cfg.Address, cfg.TLSConfig)
+	case "udp", "tcp":
+		return net.Dial(cfg.Network, cfg.Address)
+	default:
+		return nil, fmt.Errorf("syslog: unsupported network %q", cfg.Network)
+	}
+}
+
+// Export enqueues f for export. If the queue is full, the oldest queued
+// flow is dropped to make room, so Export never blocks on a slow collector.
+func (e *Exporter) Export(f *pb.Flow) error {
+	select {
+	case e.queue <- f:
+		return nil
+	default:
+	}
+
+	// Queue is full: drop the oldest entry and retry once.
+	select {
+	case <-e.queue:
+	default:
+	}
+	select {
+	case e.queue <- f:
+	default:
+	}
+	return nil
+}
+
+// Close stops the background writer and closes the underlying connection.
+func (e *Exporter) Close() error {
+	close(e.done)
+	e.wg.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn != nil {
+		return e.conn.Close()
+	}
+	return nil
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	batch := make([]*pb.Flow, 0, e.cfg.batchSize())
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		e.writeBatch(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-e.done:
+			flush()
+			return
+		case f := <-e.queue:
+			batch = append(batch, f)
+			if len(batch) >= e.cfg.batchSize() {
+				flush()
+			}
+		}
+	}
+}
+
+func (e *Exporter) writeBatch(batch []*pb.Flow) {
+	e.mu.Lock()
+	conn := e.conn
+	e.mu.Unlock()
+	if conn == nil {
+		return
+	}
+
+	for _, f := range batch {
+		msg := formatRFC5424(f, e.cfg.Facility, severityFor(f), e.cfg.tag())
+		if _, err := io.WriteString(conn, msg+"\n"); err != nil {
+			// The connection is broken; the next Export call's writes will
+			// keep failing silently until Close is called. A future
+			// revision could add reconnect-with-backoff here.
+			return
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syslog
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/cilium/hubble/api/v1/observer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityFor(t *testing.T) {
+	tests := []struct {
+		name string
+		flow *pb.Flow
+		want severity
+	}{
+		{
+			name: "dropped",
+			flow: &pb.Flow{Verdict: pb.Verdict_DROPPED},
+			want: severityWarning,
+		},
+		{
+			name: "high drop reason without dropped verdict",
+			flow: &pb.Flow{Verdict: pb.Verdict_FORWARDED, DropReason: 130},
+			want: severityWarning,
+		},
+		{
+			name: "forwarded",
+			flow: &pb.Flow{Verdict: pb.Verdict_FORWARDED},
+			want: severityInfo,
+		},
+		{
+			name: "anything else",
+			flow: &pb.Flow{},
+			want: severityNotice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, severityFor(tt.flow))
+		})
+	}
+}
+
+func TestFormatRFC5424(t *testing.T) {
+	f := &pb.Flow{
+		Verdict:  pb.Verdict_DROPPED,
+		NodeName: "k8s1",
+		Source: &pb.Endpoint{
+			Namespace: "default",
+			PodName:   "xwing",
+		},
+		IP: &pb.IP{Source: "1.1.1.1", Destination: "2.2.2.2"},
+	}
+
+	msg := formatRFC5424(f, FacilityLocal0, severityFor(f), "hubble")
+
+	assert.Contains(t, msg, "<132>1 ")
+	assert.Contains(t, msg, "k8s1 hubble")
+	assert.Contains(t, msg, `source_pod_namespace="default"`)
+	assert.Contains(t, msg, `source_pod_name="xwing"`)
+	assert.Contains(t, msg, "1.1.1.1 -> 2.2.2.2")
+}
+
+func TestEscapeSDParamValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "quote", value: `pod"name`, want: `pod\"name`},
+		{name: "backslash", value: `pod\name`, want: `pod\\name`},
+		{name: "closing-bracket", value: `pod]name`, want: `pod\]name`},
+		{name: "all-three", value: `a"b\c]d`, want: `a\"b\\c\]d`},
+		{name: "plain", value: "podname", want: "podname"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, escapeSDParamValue(tt.value))
+		})
+	}
+}
+
+func TestFormatRFC5424_escapesSpecialCharsInLabels(t *testing.T) {
+	f := &pb.Flow{
+		Source: &pb.Endpoint{
+			PodName: `xwing]"\`,
+		},
+		IP: &pb.IP{Source: "1.1.1.1", Destination: "2.2.2.2"},
+	}
+
+	msg := formatRFC5424(f, FacilityLocal0, severityFor(f), "hubble")
+
+	assert.Contains(t, msg, `source_pod_name="xwing\]\"\\"`)
+}
+
+func TestExporter_dropOldestOnFullQueue(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	e, err := New(Config{
+		Network:   "udp",
+		Address:   pc.LocalAddr().String(),
+		QueueSize: 2,
+		BatchSize: 100,
+	})
+	require.NoError(t, err)
+	// Stop the background writer before exporting, so that only the test
+	// takes flows off the queue.
+	require.NoError(t, e.Close())
+
+	require.NoError(t, e.Export(&pb.Flow{NodeName: "first"}))
+	require.NoError(t, e.Export(&pb.Flow{NodeName: "second"}))
+	require.NoError(t, e.Export(&pb.Flow{NodeName: "third"}))
+
+	// The queue can hold 2, so "first" must have been evicted.
+	var got []string
+	for len(got) < 2 {
+		select {
+		case f := <-e.queue:
+			got = append(got, f.NodeName)
+		case <-time.After(time.Second):
+			t.Fatal("timed out draining queue")
+		}
+	}
+	assert.Equal(t, []string{"second", "third"}, got)
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filter implements a CIDR and pod-name based allow/deny layer that
+// runs after parser.Decode and before a decoded flow is handed to consumers,
+// modeled on Nebula's split of LocalAllowList / RemoteAllowList.
+package filter
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/cilium/hubble/pkg/cidr"
+)
+
+// compiledNameRule is a NameRule with its pattern pre-compiled.
+type compiledNameRule struct {
+	re    *regexp.Regexp
+	allow bool
+}
+
+// AllowList evaluates IPs and "namespace/podName" strings against a set of
+// CIDR and name rules compiled from a Config.
+type AllowList struct {
+	defaultAllow bool
+	tree4        *cidr.Tree4
+	tree6        *cidr.Tree6
+	nameRules    []compiledNameRule
+}
+
+// NewAllowList compiles cfg into an AllowList. CIDR rules are inserted into
+// a radix tree so that Allowed(ip) is a single longest-prefix-match lookup
+// instead of a walk over every configured rule.
+func NewAllowList(cfg Config) (*AllowList, error) {
+	a := &AllowList{
+		defaultAllow: cfg.DefaultAllow,
+		tree4:        cidr.NewTree4(),
+		tree6:        cidr.NewTree6(),
+	}
+
+	for _, r := range cfg.Rules {
+		_, ipNet, err := net.ParseCIDR(r.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr rule %q: %w", r.CIDR, err)
+		}
+		if ipNet.IP.To4() != nil {
+			a.tree4.Insert(ipNet, r.Allow)
+		} else {
+			a.tree6.Insert(ipNet, r.Allow)
+		}
+	}
+
+	for _, nr := range cfg.NameRules {
+		re, err := regexp.Compile(nr.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod name rule %q: %w", nr.Pattern, err)
+		}
+		a.nameRules = append(a.nameRules, compiledNameRule{re: re, allow: nr.Allow})
+	}
+
+	return a, nil
+}
+
+// Allowed returns whether ip is allowed through the filter. The most
+// specific matching CIDR rule wins; if none match, DefaultAllow applies.
+func (a *AllowList) Allowed(ip net.IP) bool {
+	if a == nil {
+		return true
+	}
+
+	var v interface{}
+	var ok bool
+	if ip4 := ip.To4(); ip4 != nil {
+		v, ok = a.tree4.MostSpecificContains(ip)
+	} else {
+		v, ok = a.tree6.MostSpecificContains(ip)
+	}
+	if ok {
+		return v.(bool)
+	}
+	return a.defaultAllow
+}
+
+// AllowedPod returns whether "namespace/podName" is allowed through the
+// filter. Rules are evaluated in configuration order and the first match
+// wins; if none match, DefaultAllow applies.
+func (a *AllowList) AllowedPod(namespace, podName string) bool {
+	if a == nil {
+		return true
+	}
+
+	name := namespace + "/" + podName
+	for _, nr := range a.nameRules {
+		if nr.re.MatchString(name) {
+			return nr.allow
+		}
+	}
+	return a.defaultAllow
+}
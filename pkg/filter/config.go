@@ -0,0 +1,79 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rule is a single "cidr: allow" entry. A request matching CIDR is allowed
+// through the filter when Allow is true, and dropped when Allow is false.
+type Rule struct {
+	CIDR  string `yaml:"cidr"`
+	Allow bool   `yaml:"allow"`
+}
+
+// NameRule allows or denies flows based on a regular expression matched
+// against "namespace/podName", analogous to Nebula's AllowListNameRule.
+type NameRule struct {
+	Pattern string `yaml:"podName"`
+	Allow   bool   `yaml:"allow"`
+}
+
+// Config is the top-level YAML shape accepted by LoadConfig. DefaultAllow
+// decides the outcome for an IP or pod name that does not match any Rule or
+// NameRule.
+type Config struct {
+	DefaultAllow bool       `yaml:"defaultAllow"`
+	Rules        []Rule     `yaml:"cidrs"`
+	NameRules    []NameRule `yaml:"podNames"`
+}
+
+// RemoteConfig maps an "inside" CIDR, i.e. the local endpoint the flow is
+// attributed to, to the Config that should be applied when evaluating the
+// remote side of that flow.
+type RemoteConfig struct {
+	InsideCIDR string `yaml:"insideCIDR"`
+	Config     Config `yaml:"remote"`
+}
+
+// LoadConfig reads and parses a Config from the YAML file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadRemoteConfig reads and parses a list of RemoteConfig entries from the
+// YAML file at path.
+func LoadRemoteConfig(path string) ([]RemoteConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfgs []RemoteConfig
+	if err := yaml.Unmarshal(data, &cfgs); err != nil {
+		return nil, err
+	}
+	return cfgs, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"testing"
+
+	pb "github.com/cilium/hubble/api/v1/observer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDecoder returns a canned flow, standing in for threefour.Parser.
+type stubDecoder struct {
+	flow *pb.Flow
+}
+
+func (s *stubDecoder) Decode(_ *pb.Payload, decoded *pb.Flow) error {
+	*decoded = *s.flow
+	return nil
+}
+
+func TestFilteringDecoder_rejectsDeniedDestination(t *testing.T) {
+	allow, err := NewAllowList(Config{
+		DefaultAllow: true,
+		Rules:        []Rule{{CIDR: "10.0.0.0/8", Allow: false}},
+	})
+	require.NoError(t, err)
+
+	d := &FilteringDecoder{
+		Decoder: &stubDecoder{flow: &pb.Flow{
+			IP: &pb.IP{Source: "1.1.1.1", Destination: "10.1.2.3"},
+		}},
+		Allow: allow,
+	}
+
+	flow := &pb.Flow{}
+	err = d.Decode(&pb.Payload{}, flow)
+	assert.Equal(t, ErrFlowFiltered, err)
+}
+
+func TestFilteringDecoder_passesAllowedFlow(t *testing.T) {
+	allow, err := NewAllowList(Config{DefaultAllow: true})
+	require.NoError(t, err)
+
+	d := &FilteringDecoder{
+		Decoder: &stubDecoder{flow: &pb.Flow{
+			IP: &pb.IP{Source: "1.1.1.1", Destination: "2.2.2.2"},
+		}},
+		Allow: allow,
+	}
+
+	flow := &pb.Flow{}
+	err = d.Decode(&pb.Payload{}, flow)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.2.2.2", flow.GetIP().GetDestination())
+}
+
+func TestFilteringDecoder_rejectsViaRemoteAllowList(t *testing.T) {
+	dflt, err := NewAllowList(Config{DefaultAllow: false})
+	require.NoError(t, err)
+	remote, err := NewRemoteAllowList(nil, dflt)
+	require.NoError(t, err)
+
+	d := &FilteringDecoder{
+		Decoder: &stubDecoder{flow: &pb.Flow{
+			IP: &pb.IP{Source: "1.1.1.1", Destination: "2.2.2.2"},
+		}},
+		Remote: remote,
+	}
+
+	flow := &pb.Flow{}
+	err = d.Decode(&pb.Payload{}, flow)
+	assert.Equal(t, ErrFlowFiltered, err)
+}
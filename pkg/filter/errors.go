@@ -0,0 +1,23 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import "errors"
+
+// ErrFlowFiltered is returned by FilteringDecoder.Decode when the decoded
+// flow was dropped by the configured AllowList or RemoteAllowList. Callers that only care about whether
+// a flow should be forwarded to consumers can compare the error returned by
+// Decode against this sentinel instead of inspecting the flow itself.
+var ErrFlowFiltered = errors.New("flow filtered by allow/deny list")
@@ -0,0 +1,112 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"net"
+	"testing"
+
+	v1 "github.com/cilium/hubble/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowList_allowByDefault(t *testing.T) {
+	al, err := NewAllowList(Config{DefaultAllow: true})
+	require.NoError(t, err)
+	assert.True(t, al.Allowed(net.ParseIP("10.0.0.1")))
+}
+
+func TestAllowList_denyByDefault(t *testing.T) {
+	al, err := NewAllowList(Config{DefaultAllow: false})
+	require.NoError(t, err)
+	assert.False(t, al.Allowed(net.ParseIP("10.0.0.1")))
+}
+
+func TestAllowList_mostSpecificRuleWins(t *testing.T) {
+	al, err := NewAllowList(Config{
+		DefaultAllow: false,
+		Rules: []Rule{
+			{CIDR: "10.0.0.0/8", Allow: true},
+			{CIDR: "10.1.0.0/16", Allow: false},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, al.Allowed(net.ParseIP("10.2.0.1")))
+	assert.False(t, al.Allowed(net.ParseIP("10.1.0.1")))
+}
+
+func TestAllowList_nameRules(t *testing.T) {
+	al, err := NewAllowList(Config{
+		DefaultAllow: true,
+		NameRules: []NameRule{
+			{Pattern: `^kube-system/`, Allow: false},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, al.AllowedPod("kube-system", "coredns-123"))
+	assert.True(t, al.AllowedPod("default", "xwing"))
+}
+
+func TestRemoteAllowList_perEndpointOverride(t *testing.T) {
+	dflt, err := NewAllowList(Config{DefaultAllow: true})
+	require.NoError(t, err)
+
+	r, err := NewRemoteAllowList([]RemoteConfig{
+		{InsideCIDR: "10.16.0.0/16", Config: Config{DefaultAllow: false, Rules: []Rule{{CIDR: "192.168.0.0/16", Allow: true}}}},
+	}, dflt)
+	require.NoError(t, err)
+
+	// Endpoint inside the overridden CIDR only allows the configured rule.
+	assert.True(t, r.Allowed(nil, net.ParseIP("10.16.1.1"), net.ParseIP("192.168.1.1")))
+	assert.False(t, r.Allowed(nil, net.ParseIP("10.16.1.1"), net.ParseIP("8.8.8.8")))
+
+	// Endpoint outside the overridden CIDR falls back to the default list.
+	assert.True(t, r.Allowed(nil, net.ParseIP("172.16.0.1"), net.ParseIP("8.8.8.8")))
+}
+
+func TestRemoteAllowList_resolvesThroughEndpointGetter(t *testing.T) {
+	dflt, err := NewAllowList(Config{DefaultAllow: true})
+	require.NoError(t, err)
+
+	r, err := NewRemoteAllowList([]RemoteConfig{
+		{InsideCIDR: "10.16.0.0/16", Config: Config{DefaultAllow: false}},
+	}, dflt)
+	require.NoError(t, err)
+
+	// The flow's local IP (an ephemeral/allocator address) isn't itself
+	// inside the overridden CIDR, but the endpoint it belongs to is -- the
+	// getter should be used to resolve that before picking a rule set.
+	getter := &fakeEndpointGetter{
+		ip:  net.ParseIP("192.0.2.1"),
+		out: &v1.Endpoint{IPv4: net.ParseIP("10.16.5.5")},
+	}
+
+	assert.False(t, r.Allowed(getter, net.ParseIP("192.0.2.1"), net.ParseIP("8.8.8.8")))
+}
+
+type fakeEndpointGetter struct {
+	ip  net.IP
+	out *v1.Endpoint
+}
+
+func (f *fakeEndpointGetter) GetEndpoint(ip net.IP) (*v1.Endpoint, bool) {
+	if ip.Equal(f.ip) {
+		return f.out, true
+	}
+	return nil, false
+}
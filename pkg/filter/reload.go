@@ -0,0 +1,60 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"os"
+	"time"
+)
+
+// WatchConfig polls path for modifications every interval and calls onReload
+// with a freshly compiled AllowList whenever its mtime changes. It returns a
+// stop function that terminates the watch goroutine. Parse errors are
+// reported to onReload with a nil AllowList so that callers can log and keep
+// serving the previously-loaded list.
+func WatchConfig(path string, interval time.Duration, onReload func(*AllowList, error)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		var lastMod time.Time
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fi, err := os.Stat(path)
+				if err != nil {
+					onReload(nil, err)
+					continue
+				}
+				if !fi.ModTime().After(lastMod) {
+					continue
+				}
+				lastMod = fi.ModTime()
+
+				cfg, err := LoadConfig(path)
+				if err != nil {
+					onReload(nil, err)
+					continue
+				}
+				al, err := NewAllowList(*cfg)
+				onReload(al, err)
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
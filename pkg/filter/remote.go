@@ -0,0 +1,111 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"net"
+
+	v1 "github.com/cilium/hubble/pkg/api/v1"
+	"github.com/cilium/hubble/pkg/cidr"
+)
+
+// EndpointGetter is the same local endpoint lookup threaded through the
+// parser's constructor (see threefour.New), reused here so a RemoteAllowList
+// can resolve which local pod/endpoint a flow is attributed to from the
+// flow's local IP, rather than matching on that IP directly.
+type EndpointGetter interface {
+	GetEndpoint(ip net.IP) (endpoint *v1.Endpoint, ok bool)
+}
+
+// RemoteAllowList selects an AllowList to apply to the remote side of a flow
+// based on which "inside" CIDR the flow's local endpoint falls into. This
+// lets different rule sets apply depending on which local pod or endpoint
+// the flow is attributed to, mirroring Nebula's RemoteAllowList.
+type RemoteAllowList struct {
+	tree4 *cidr.Tree4
+	tree6 *cidr.Tree6
+	dflt  *AllowList
+}
+
+// NewRemoteAllowList compiles cfgs into a RemoteAllowList. dflt is applied
+// when the local endpoint does not fall within any configured inside CIDR.
+func NewRemoteAllowList(cfgs []RemoteConfig, dflt *AllowList) (*RemoteAllowList, error) {
+	r := &RemoteAllowList{
+		tree4: cidr.NewTree4(),
+		tree6: cidr.NewTree6(),
+		dflt:  dflt,
+	}
+
+	for _, c := range cfgs {
+		_, ipNet, err := net.ParseCIDR(c.InsideCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inside cidr %q: %w", c.InsideCIDR, err)
+		}
+		al, err := NewAllowList(c.Config)
+		if err != nil {
+			return nil, fmt.Errorf("inside cidr %q: %w", c.InsideCIDR, err)
+		}
+		if ipNet.IP.To4() != nil {
+			r.tree4.Insert(ipNet, al)
+		} else {
+			r.tree6.Insert(ipNet, al)
+		}
+	}
+
+	return r, nil
+}
+
+// Allowed returns whether remoteIP is allowed through the filter, using the
+// rule set selected by which inside CIDR the flow's local endpoint belongs
+// to. getter is consulted to resolve localIP to its owning endpoint so that
+// the endpoint's own address -- rather than whatever interface address the
+// flow happened to carry -- picks the rule set: its IPv4 address when
+// localIP is IPv4 and the endpoint has one, otherwise its IPv6 address if it
+// has one. getter may be nil, in which case localIP is used as-is.
+func (r *RemoteAllowList) Allowed(getter EndpointGetter, localIP, remoteIP net.IP) bool {
+	if r == nil {
+		return true
+	}
+
+	insideIP := localIP
+	if getter != nil {
+		if ep, ok := getter.GetEndpoint(localIP); ok {
+			if ep.IPv4 != nil && localIP.To4() != nil {
+				insideIP = ep.IPv4
+			} else if ep.IPv6 != nil {
+				insideIP = ep.IPv6
+			}
+		}
+	}
+
+	return r.allowListFor(insideIP).Allowed(remoteIP)
+}
+
+// allowListFor returns the most specific AllowList whose inside CIDR covers
+// localIP, falling back to the default AllowList.
+func (r *RemoteAllowList) allowListFor(localIP net.IP) *AllowList {
+	var v interface{}
+	var ok bool
+	if ip4 := localIP.To4(); ip4 != nil {
+		v, ok = r.tree4.MostSpecificContains(localIP)
+	} else {
+		v, ok = r.tree6.MostSpecificContains(localIP)
+	}
+	if ok {
+		return v.(*AllowList)
+	}
+	return r.dflt
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"net"
+	"sync"
+)
+
+// podInfo is the namespace/name pair a cache entry resolves to. For an entry
+// keyed by a service's ClusterIP, name is the service name rather than a pod
+// name.
+type podInfo struct {
+	namespace string
+	name      string
+}
+
+// resolverCache is a thread-safe cache of Pod/Endpoints/Service IPs, kept as
+// three maps from an IP (pod IP, host IP, or ClusterIP/endpoint address) to
+// the namespace/name it belongs to, so that GetPodNameOf is an O(1) hash hit
+// instead of a walk over every known pod. Each IP maps to a single owner: a
+// later write for the same IP replaces the earlier one.
+type resolverCache struct {
+	mu sync.RWMutex
+
+	byPodIP     map[string]podInfo
+	byHostIP    map[string]podInfo
+	byClusterIP map[string]podInfo
+}
+
+func newResolverCache() *resolverCache {
+	return &resolverCache{
+		byPodIP:     make(map[string]podInfo),
+		byHostIP:    make(map[string]podInfo),
+		byClusterIP: make(map[string]podInfo),
+	}
+}
+
+func (c *resolverCache) setPod(namespace, name, podIP, hostIP string) {
+	info := podInfo{namespace: namespace, name: name}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if podIP != "" {
+		c.byPodIP[podIP] = info
+	}
+	if hostIP != "" {
+		c.byHostIP[hostIP] = info
+	}
+}
+
+func (c *resolverCache) deletePod(podIP, hostIP string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.byPodIP, podIP)
+	delete(c.byHostIP, hostIP)
+}
+
+func (c *resolverCache) setService(namespace, name, clusterIP string) {
+	if clusterIP == "" || clusterIP == "None" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byClusterIP[clusterIP] = podInfo{namespace: namespace, name: name}
+}
+
+func (c *resolverCache) deleteService(clusterIP string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.byClusterIP, clusterIP)
+}
+
+// getPodNameOf looks ip up in the pod, host and service IP buckets, in that
+// order.
+func (c *resolverCache) getPodNameOf(ip net.IP) (namespace, name string, ok bool) {
+	key := ip.String()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if info, found := c.byPodIP[key]; found {
+		return info.namespace, info.name, true
+	}
+	if info, found := c.byHostIP[key]; found {
+		return info.namespace, info.name, true
+	}
+	if info, found := c.byClusterIP[key]; found {
+		return info.namespace, info.name, true
+	}
+	return "", "", false
+}
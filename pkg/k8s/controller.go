@@ -0,0 +1,274 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8s resolves pod/namespace/service attribution for an IP straight
+// from the Kubernetes API, via a SharedInformerFactory, instead of relying
+// exclusively on the Cilium agent's IPCache. Depending on its Mode it is
+// consulted after (ModeFallback) or ahead of (ModePrimary) the existing
+// IPCache path.
+package k8s
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// resourceKey identifies the object a queued work item refers to, since a
+// single workqueue is shared across the three informers.
+type resourceKey struct {
+	resource string // "pods", "endpoints" or "services"
+	key      string // namespace/name, per cache.SplitMetaNamespaceKey
+}
+
+// Controller runs SharedInformers for Pods, Endpoints and Services and
+// maintains an indexed cache of PodIP/HostIP/ClusterIP -> namespace/name, so
+// that ObserverServer can enrich flows with pod/namespace/service labels
+// without depending on the Cilium agent's IPCache being reachable.
+type Controller struct {
+	mode Mode
+
+	factory informers.SharedInformerFactory
+
+	pods      coreinformers.PodInformer
+	endpoints coreinformers.EndpointsInformer
+	services  coreinformers.ServiceInformer
+
+	podLister       corelisters.PodLister
+	serviceLister   corelisters.ServiceLister
+	endpointsLister corelisters.EndpointsLister
+
+	queue workqueue.RateLimitingInterface
+	cache *resolverCache
+}
+
+// NewController builds a Controller backed by client, but does not start
+// any informers; call Run to do so.
+func NewController(client kubernetes.Interface, resync time.Duration, mode Mode) *Controller {
+	factory := informers.NewSharedInformerFactory(client, resync)
+
+	c := &Controller{
+		mode:      mode,
+		factory:   factory,
+		pods:      factory.Core().V1().Pods(),
+		endpoints: factory.Core().V1().Endpoints(),
+		services:  factory.Core().V1().Services(),
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		cache:     newResolverCache(),
+	}
+	c.podLister = c.pods.Lister()
+	c.serviceLister = c.services.Lister()
+	c.endpointsLister = c.endpoints.Lister()
+
+	c.pods.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue("pods", obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue("pods", obj) },
+		DeleteFunc: c.deletePod,
+	})
+	c.services.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue("services", obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue("services", obj) },
+		DeleteFunc: c.deleteService,
+	})
+	c.endpoints.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue("endpoints", obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue("endpoints", obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueue("endpoints", obj) },
+	})
+
+	return c
+}
+
+func (c *Controller) enqueue(resource string, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(resourceKey{resource: resource, key: key})
+}
+
+// Run starts the informers, waits for the initial list to complete, and
+// processes the workqueue with the given number of workers until stopCh is
+// closed.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	if c.mode == ModeOff {
+		<-stopCh
+		return nil
+	}
+
+	c.factory.Start(stopCh)
+
+	if !cache.WaitForCacheSync(stopCh, c.pods.Informer().HasSynced, c.services.Informer().HasSynced, c.endpoints.Informer().HasSynced) {
+		return fmt.Errorf("k8s: timed out waiting for informer caches to sync")
+	}
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(c.runWorker, time.Second, stopCh)
+	}
+
+	<-stopCh
+	return nil
+}
+
+// HasSynced reports whether the pod, service and endpoints informers have
+// completed their initial list. Items from that list may still be waiting in
+// the workqueue, so GetPodNameOf can miss for a short while after HasSynced
+// first returns true. ObserverServer should gate serving enriched flows on
+// this.
+func (c *Controller) HasSynced() bool {
+	if c.mode == ModeOff {
+		return true
+	}
+	return c.pods.Informer().HasSynced() && c.services.Informer().HasSynced() && c.endpoints.Informer().HasSynced()
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextItem() {
+	}
+}
+
+func (c *Controller) processNextItem() bool {
+	item, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	key := item.(resourceKey)
+	if err := c.process(key); err != nil {
+		runtime.HandleError(fmt.Errorf("k8s: requeuing %v/%s: %w", key.resource, key.key, err))
+		c.queue.AddRateLimited(item)
+		return true
+	}
+
+	c.queue.Forget(item)
+	return true
+}
+
+func (c *Controller) process(rk resourceKey) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(rk.key)
+	if err != nil {
+		return err
+	}
+
+	switch rk.resource {
+	case "pods":
+		return c.processPod(namespace, name)
+	case "services":
+		return c.processService(namespace, name)
+	case "endpoints":
+		return c.processEndpoints(namespace, name)
+	default:
+		return fmt.Errorf("unknown resource %q", rk.resource)
+	}
+}
+
+func (c *Controller) processPod(namespace, name string) error {
+	pod, err := c.podLister.Pods(namespace).Get(name)
+	if err != nil {
+		// Not found here means the pod's own Delete event just hasn't run
+		// deletePod yet, or already has; either way there is nothing to do
+		// with only a namespace/name key and no IPs to evict.
+		return nil
+	}
+	c.cache.setPod(namespace, name, pod.Status.PodIP, pod.Status.HostIP)
+	return nil
+}
+
+func (c *Controller) processService(namespace, name string) error {
+	svc, err := c.serviceLister.Services(namespace).Get(name)
+	if err != nil {
+		// See processPod: eviction on delete is handled by deleteService.
+		return nil
+	}
+	if svc.Spec.Type != corev1.ServiceTypeExternalName {
+		c.cache.setService(namespace, name, svc.Spec.ClusterIP)
+	}
+	return nil
+}
+
+// deletedObject returns the object a DeleteFunc handler was called with,
+// unwrapping the cache.DeletedFinalStateUnknown tombstone client-go uses
+// when it missed the actual delete event while the watch was disconnected.
+func deletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
+
+// deletePod evicts a deleted pod's IPs from the cache immediately, since by
+// the time a queued resourceKey for it would be processed, its IPs are no
+// longer available from the podLister to look up.
+func (c *Controller) deletePod(obj interface{}) {
+	pod, ok := deletedObject(obj).(*corev1.Pod)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("k8s: unexpected delete object type %T", obj))
+		return
+	}
+	c.cache.deletePod(pod.Status.PodIP, pod.Status.HostIP)
+}
+
+// deleteService evicts a deleted service's ClusterIP from the cache, for the
+// same reason deletePod does.
+func (c *Controller) deleteService(obj interface{}) {
+	svc, ok := deletedObject(obj).(*corev1.Service)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("k8s: unexpected delete object type %T", obj))
+		return
+	}
+	c.cache.deleteService(svc.Spec.ClusterIP)
+}
+
+// processEndpoints keys every address of every Endpoints object to that
+// service's name, so that addresses of a service with no selector (whose
+// Endpoints are populated by hand or by an external controller rather than
+// derived from Pod labels) get attributed, which processPod and
+// processService alone never do. An address that is also a known pod IP
+// still resolves to the pod, since getPodNameOf checks pod IPs first.
+func (c *Controller) processEndpoints(namespace, name string) error {
+	ep, err := c.endpointsLister.Endpoints(namespace).Get(name)
+	if err != nil {
+		return nil
+	}
+
+	for _, subset := range ep.Subsets {
+		for _, addr := range subset.Addresses {
+			c.cache.setService(namespace, name, addr.IP)
+		}
+	}
+	return nil
+}
+
+// GetPodNameOf returns the namespace and name of the pod or service that ip
+// belongs to, so that it can plug into the existing podGetter/
+// endpointsHandler fallback chain (IPCache -> informer cache -> endpoint
+// handler).
+func (c *Controller) GetPodNameOf(ip net.IP) (namespace, name string, ok bool) {
+	return c.cache.getPodNameOf(ip)
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMode(t *testing.T) {
+	for _, m := range []Mode{ModeOff, ModeFallback, ModePrimary} {
+		got, err := ParseMode(string(m))
+		require.NoError(t, err)
+		assert.Equal(t, m, got)
+	}
+
+	_, err := ParseMode("bogus")
+	assert.Error(t, err)
+}
+
+func TestController_resolvesPodsAndServices(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "xwing", Namespace: "default"},
+			Status:     corev1.PodStatus{PodIP: "10.16.236.178", HostIP: "192.168.33.11"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "deathstar", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.1"},
+		},
+	)
+
+	c := NewController(client, 0, ModePrimary)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() { _ = c.Run(1, stopCh) }()
+
+	require.Eventually(t, c.HasSynced, time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		_, _, ok := c.GetPodNameOf(net.ParseIP("10.16.236.178"))
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	ns, name, ok := c.GetPodNameOf(net.ParseIP("10.16.236.178"))
+	assert.True(t, ok)
+	assert.Equal(t, "default", ns)
+	assert.Equal(t, "xwing", name)
+
+	ns, name, ok = c.GetPodNameOf(net.ParseIP("192.168.33.11"))
+	assert.True(t, ok)
+	assert.Equal(t, "default", ns)
+	assert.Equal(t, "xwing", name)
+
+	require.Eventually(t, func() bool {
+		_, _, ok := c.GetPodNameOf(net.ParseIP("10.96.0.1"))
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	ns, name, ok = c.GetPodNameOf(net.ParseIP("10.96.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "default", ns)
+	assert.Equal(t, "deathstar", name)
+}
+
+func TestController_evictsOnDelete(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "xwing", Namespace: "default"},
+			Status:     corev1.PodStatus{PodIP: "10.16.236.178", HostIP: "192.168.33.11"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "deathstar", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.1"},
+		},
+	)
+
+	c := NewController(client, 0, ModePrimary)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() { _ = c.Run(1, stopCh) }()
+
+	require.Eventually(t, func() bool {
+		_, _, ok := c.GetPodNameOf(net.ParseIP("10.16.236.178"))
+		return ok
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, _, ok := c.GetPodNameOf(net.ParseIP("10.96.0.1"))
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.CoreV1().Pods("default").Delete("xwing", &metav1.DeleteOptions{}))
+	require.NoError(t, client.CoreV1().Services("default").Delete("deathstar", &metav1.DeleteOptions{}))
+
+	require.Eventually(t, func() bool {
+		_, _, ok := c.GetPodNameOf(net.ParseIP("10.16.236.178"))
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	_, _, ok := c.GetPodNameOf(net.ParseIP("192.168.33.11"))
+	assert.False(t, ok)
+
+	require.Eventually(t, func() bool {
+		_, _, ok := c.GetPodNameOf(net.ParseIP("10.96.0.1"))
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestController_modeOff_neverSyncs(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := NewController(client, 0, ModeOff)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() { _ = c.Run(1, stopCh) }()
+
+	assert.True(t, c.HasSynced())
+
+	_, _, ok := c.GetPodNameOf(net.ParseIP("1.1.1.1"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import "fmt"
+
+// Mode selects how authoritative the informer-backed cache in this package
+// is relative to the Cilium agent's IPCache. No command-line flag in this
+// tree sets it; callers pass it to NewController and Chain directly.
+type Mode string
+
+const (
+	// ModeOff disables the informer cache entirely; pod/namespace/service
+	// enrichment relies solely on IPCache.
+	ModeOff Mode = "off"
+	// ModeFallback runs the informer cache but only consults it when
+	// IPCache has no entry for a given IP. An IPCache entry that is present
+	// is used as is; stale entries are not detected.
+	ModeFallback Mode = "fallback"
+	// ModePrimary treats the informer cache as authoritative and only
+	// falls back to IPCache when it has no answer.
+	ModePrimary Mode = "primary"
+)
+
+// ParseMode parses a Mode from its string form: "off", "fallback" or
+// "primary".
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModeOff, ModeFallback, ModePrimary:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid k8s mode %q: must be one of %q, %q, %q", s, ModeOff, ModeFallback, ModePrimary)
+	}
+}
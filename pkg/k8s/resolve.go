@@ -0,0 +1,58 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import "net"
+
+// PodNameGetter is satisfied by both Controller and the Cilium agent's
+// existing IPCache/LegacyPodGetter path, so the two can be chained into a
+// single lookup regardless of which one is authoritative.
+type PodNameGetter interface {
+	GetPodNameOf(ip net.IP) (namespace, name string, ok bool)
+}
+
+// Chain composes legacy (the existing IPCache/LegacyPodGetter lookup) and
+// informer (this package's Controller) into a single PodNameGetter, trying
+// them in the order mode specifies:
+//
+//   - ModeOff:      legacy only; informer is never consulted.
+//   - ModeFallback: legacy first, informer only on a miss.
+//   - ModePrimary:  informer first, legacy only on a miss.
+//
+// This is the "IPCache -> informer cache -> endpoint handler" fallback chain
+// described on Controller.GetPodNameOf, and is the integration point a
+// caller wiring up ObserverServer should use in place of a bare
+// LegacyPodGetter when the Mode is anything other than ModeOff.
+func Chain(mode Mode, legacy, informer PodNameGetter) PodNameGetter {
+	switch mode {
+	case ModeOff:
+		return legacy
+	case ModePrimary:
+		return fallbackGetter{first: informer, second: legacy}
+	default: // ModeFallback
+		return fallbackGetter{first: legacy, second: informer}
+	}
+}
+
+type fallbackGetter struct {
+	first, second PodNameGetter
+}
+
+func (f fallbackGetter) GetPodNameOf(ip net.IP) (namespace, name string, ok bool) {
+	if namespace, name, ok = f.first.GetPodNameOf(ip); ok {
+		return namespace, name, true
+	}
+	return f.second.GetPodNameOf(ip)
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePodNameGetter struct {
+	ns, name string
+	ok       bool
+}
+
+func (f fakePodNameGetter) GetPodNameOf(_ net.IP) (string, string, bool) {
+	return f.ns, f.name, f.ok
+}
+
+func TestChain_modeOff_usesLegacyOnly(t *testing.T) {
+	legacy := fakePodNameGetter{ns: "default", name: "xwing", ok: true}
+	informer := fakePodNameGetter{ok: false}
+
+	g := Chain(ModeOff, legacy, informer)
+	ns, name, ok := g.GetPodNameOf(net.ParseIP("10.0.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "default", ns)
+	assert.Equal(t, "xwing", name)
+}
+
+func TestChain_modeFallback_prefersLegacy(t *testing.T) {
+	legacy := fakePodNameGetter{ns: "default", name: "xwing", ok: true}
+	informer := fakePodNameGetter{ns: "default", name: "ywing", ok: true}
+
+	g := Chain(ModeFallback, legacy, informer)
+	_, name, ok := g.GetPodNameOf(net.ParseIP("10.0.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "xwing", name)
+}
+
+func TestChain_modeFallback_fallsBackToInformer(t *testing.T) {
+	legacy := fakePodNameGetter{ok: false}
+	informer := fakePodNameGetter{ns: "default", name: "ywing", ok: true}
+
+	g := Chain(ModeFallback, legacy, informer)
+	_, name, ok := g.GetPodNameOf(net.ParseIP("10.0.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "ywing", name)
+}
+
+func TestChain_modePrimary_prefersInformer(t *testing.T) {
+	legacy := fakePodNameGetter{ns: "default", name: "xwing", ok: true}
+	informer := fakePodNameGetter{ns: "default", name: "ywing", ok: true}
+
+	g := Chain(ModePrimary, legacy, informer)
+	_, name, ok := g.GetPodNameOf(net.ParseIP("10.0.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "ywing", name)
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Authors of Hubble
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package threefour
+
+import (
+	"net"
+	"strings"
+)
+
+const cidrLabelPrefix = "cidr:"
+
+// filterCidrLabels removes all "cidr:" prefixed labels from labels, except
+// for the most specific one in each of the IPv4 and IPv6 families, i.e. the
+// one with the longest prefix per family. Every non-cidr label is passed
+// through unmodified.
+//
+// Identities can carry several "cidr:a.b.c.d/n" labels describing the same
+// endpoint at different levels of aggregation (e.g. both a /24 and a /32);
+// only the most specific one is useful to a consumer of the flow. Each label
+// is the network address for its own mask, not a shared host address, so a
+// /16 network address like "1.1.0.0" and a /24 one like "1.1.1.0" can
+// diverge outside the masked bits -- a longest-prefix-match lookup keyed on
+// either one is not guaranteed to reach the other. The prefix lengths
+// themselves are compared directly instead, which depends only on the
+// labels present, not on which of them happened to be parsed last.
+func filterCidrLabels(labels []string) []string {
+	var filtered []string
+	var best4, best6 string
+	bestOnes4, bestOnes6 := -1, -1
+
+	for _, l := range labels {
+		if !strings.HasPrefix(l, cidrLabelPrefix) {
+			filtered = append(filtered, l)
+			continue
+		}
+
+		ip, ipNet, err := net.ParseCIDR(strings.TrimPrefix(l, cidrLabelPrefix))
+		if err != nil {
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+
+		if ip.To4() != nil {
+			if ones > bestOnes4 {
+				bestOnes4, best4 = ones, l
+			}
+		} else {
+			if ones > bestOnes6 {
+				bestOnes6, best6 = ones, l
+			}
+		}
+	}
+
+	if bestOnes4 >= 0 {
+		filtered = append(filtered, best4)
+	}
+	if bestOnes6 >= 0 {
+		filtered = append(filtered, best6)
+	}
+
+	return filtered
+}
@@ -419,6 +419,31 @@ func Test_filterCidrLabels(t *testing.T) {
 			},
 			want: nil,
 		},
+		{
+			name: "mixed-ipv4-ipv6",
+			args: args{
+				labels: []string{
+					"cidr:1.1.0.0/16", "cidr:1.1.1.0/24",
+					"cidr:fd00::/8", "cidr:fd00:10::/32",
+					"some=label",
+				},
+			},
+			want: []string{"some=label", "cidr:1.1.1.0/24", "cidr:fd00:10::/32"},
+		},
+		{
+			// Same labels as "mixed-ipv4-ipv6" but with the more specific
+			// prefix of each family listed first: the result must not
+			// depend on parse order, only on prefix length.
+			name: "mixed-ipv4-ipv6-reverse-order",
+			args: args{
+				labels: []string{
+					"some=label",
+					"cidr:fd00:10::/32", "cidr:fd00::/8",
+					"cidr:1.1.1.0/24", "cidr:1.1.0.0/16",
+				},
+			},
+			want: []string{"some=label", "cidr:1.1.1.0/24", "cidr:fd00:10::/32"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -429,6 +454,20 @@ func Test_filterCidrLabels(t *testing.T) {
 	}
 }
 
+func BenchmarkFilterCidrLabels(b *testing.B) {
+	labels := []string{
+		"some=label", "another=label",
+		"cidr:10.0.0.0/8", "cidr:10.16.0.0/16", "cidr:10.16.236.0/24",
+		"cidr:fd00::/8", "cidr:fd00:10::/32",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = filterCidrLabels(labels)
+	}
+}
+
 func TestTraceNotifyOriginalIP(t *testing.T) {
 	f := &pb.Flow{}
 	parser, err := New(&testutils.NoopEndpointGetter, nil, &testutils.NoopDNSGetter, &testutils.NoopK8sGetter)